@@ -0,0 +1,143 @@
+package ofxgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Profile fetches fiURL's OFX profile (via PROFRQ), identifying which
+// message sets and OFX versions the FI supports and what its signon
+// requirements are. Profiles are cached on disk keyed by (org, fid), so
+// repeated calls for the same FI don't re-query it; delete the cache file
+// (see profileCacheFile) to force a refresh.
+//
+// A successful call also populates c.CachedProfile, which Request consults
+// to avoid sending message sets the FI doesn't advertise support for, and
+// records the OFX version the FI replied with, which Request uses whenever
+// SpecVersion is unset.
+func (c *Client) Profile(fiURL, org, fid string) (*ProfileResponse, error) {
+	return c.ProfileContext(context.Background(), fiURL, org, fid)
+}
+
+// ProfileContext is identical to Profile, except that it allows the caller
+// to pass a context.Context to bound/cancel the underlying HTTP request.
+func (c *Client) ProfileContext(ctx context.Context, fiURL, org, fid string) (*ProfileResponse, error) {
+	if cached := c.loadCachedProfile(org, fid); cached != nil {
+		c.CachedProfile = cached.Profile
+		c.cachedProfileVersion = cached.Version
+		return cached.Profile, nil
+	}
+
+	r := Request{
+		URL: fiURL,
+		Signon: SignonRequest{
+			Org:      String(org),
+			Fid:      String(fid),
+			UserId:   String("anonymous00000000000000000000000"),
+			UserPass: String(""),
+		},
+		Profile: []Message{
+			&ProfileRequest{
+				TrnUID:        String(time.Now().Format("20060102150405.000000")),
+				ClientRouting: String("NONE"),
+				DtProfUp:      Date(time.Unix(0, 0)),
+			},
+		},
+	}
+
+	resp, err := c.RequestContext(ctx, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range resp.Profile {
+		if pr, ok := msg.(*ProfileResponse); ok {
+			c.CachedProfile = pr
+			c.cachedProfileVersion = resp.Version
+			entry := profileCacheEntry{Version: resp.Version, Profile: pr}
+			// A failure to persist the on-disk cache doesn't invalidate the
+			// profile just fetched from the FI; it just means the next call
+			// won't find it cached.
+			_ = c.saveCachedProfile(org, fid, entry)
+			return pr, nil
+		}
+	}
+	return nil, errors.New("FI did not return a profile response")
+}
+
+// profileCacheEntry is what Profile persists to disk for each (org, fid)
+// it has queried: the raw decoded profile, plus the OFX SpecVersion the FI
+// used to reply to the PROFRQ (a reasonable version to negotiate with on
+// future requests, since the FI just demonstrated it understands it).
+type profileCacheEntry struct {
+	Version string           `json:"version"`
+	Profile *ProfileResponse `json:"profile"`
+}
+
+// profileCacheFile returns the path ofxgo stores cached FI profiles in,
+// honoring Client.ProfileCacheDir if the caller set one.
+func (c *Client) profileCacheFile() (string, error) {
+	dir := c.ProfileCacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userCacheDir, "ofxgo")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles.json"), nil
+}
+
+func profileCacheMapKey(org, fid string) string {
+	return org + "|" + fid
+}
+
+func (c *Client) loadCachedProfile(org, fid string) *profileCacheEntry {
+	path, err := c.profileCacheFile()
+	if err != nil {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cache map[string]profileCacheEntry
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return nil
+	}
+	if entry, ok := cache[profileCacheMapKey(org, fid)]; ok {
+		return &entry
+	}
+	return nil
+}
+
+// saveCachedProfile merges entry into the on-disk cache file, leaving
+// entries for other (org, fid) pairs untouched.
+func (c *Client) saveCachedProfile(org, fid string, entry profileCacheEntry) error {
+	path, err := c.profileCacheFile()
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[string]profileCacheEntry)
+	if b, err := ioutil.ReadFile(path); err == nil {
+		// A corrupt cache file is simply overwritten below rather than
+		// treated as fatal.
+		json.Unmarshal(b, &cache)
+	}
+	cache[profileCacheMapKey(org, fid)] = entry
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
@@ -0,0 +1,98 @@
+package ofxgo
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// CCAcctFrom identifies the credit card account a statement request or
+// response pertains to. Unlike BankAcctFrom, credit card accounts have no
+// separate account type or routing number.
+type CCAcctFrom struct {
+	AcctId String `xml:"ACCTID"`
+}
+
+func (a *CCAcctFrom) Valid() (bool, error) {
+	if len(a.AcctId) == 0 {
+		return false, errors.New("CCAcctFrom: ACCTID unset")
+	}
+	return true, nil
+}
+
+// CCStatementRequest represents a CCSTMTTRNRQ, requesting the transactions
+// and balances for a single credit card account.
+type CCStatementRequest struct {
+	XMLName   xml.Name `xml:"CCSTMTTRNRQ"`
+	TrnUID    String   `xml:"TRNUID"`
+	CltCookie String   `xml:"CLTCOOKIE,omitempty"`
+
+	CCAcctFrom CCAcctFrom `xml:"CCSTMTRQ>CCACCTFROM"`
+	DtStart    *Date      `xml:"CCSTMTRQ>INCTRAN>DTSTART,omitempty"`
+	DtEnd      *Date      `xml:"CCSTMTRQ>INCTRAN>DTEND,omitempty"`
+	Include    bool       `xml:"CCSTMTRQ>INCTRAN>INCLUDE"` // Include transactions (instead of just balance)
+}
+
+func (r *CCStatementRequest) Name() string {
+	return "CCSTMTTRNRQ"
+}
+
+func (r *CCStatementRequest) Valid() (bool, error) {
+	if len(r.TrnUID) == 0 {
+		return false, errors.New("CCStatementRequest: TrnUID unset")
+	}
+	return r.CCAcctFrom.Valid()
+}
+
+// CCStatementResponse represents a CCSTMTTRNRS, the reply to a
+// CCStatementRequest.
+type CCStatementResponse struct {
+	XMLName   xml.Name `xml:"CCSTMTTRNRS"`
+	TrnUID    String   `xml:"TRNUID"`
+	Status    Status   `xml:"STATUS"`
+	CltCookie String   `xml:"CLTCOOKIE,omitempty"`
+
+	CurDef       String     `xml:"CCSTMTRS>CURDEF"`
+	CCAcctFrom   CCAcctFrom `xml:"CCSTMTRS>CCACCTFROM"`
+	BankTranList *TranList  `xml:"CCSTMTRS>BANKTRANLIST,omitempty"`
+	AvailBal     *Balance   `xml:"CCSTMTRS>AVAILBAL,omitempty"`
+	LedgerBal    Balance    `xml:"CCSTMTRS>LEDGERBAL"`
+}
+
+func (cc *CCStatementResponse) Name() string {
+	return "CCSTMTTRNRS"
+}
+
+func (cc *CCStatementResponse) Valid() (bool, error) {
+	if len(cc.TrnUID) == 0 {
+		return false, errors.New("CCStatementResponse: TrnUID unset")
+	}
+	return true, nil
+}
+
+// DecodeCreditCardMessageSet consumes a CREDITCARDMSGSRSV1 element (and
+// everything inside it), returning the decoded CCSTMTTRNRS messages it
+// contains.
+func DecodeCreditCardMessageSet(d *xml.Decoder, start xml.StartElement) ([]Message, error) {
+	var msgs []Message
+	for {
+		tok, err := nextNonWhitespaceToken(d)
+		if err != nil {
+			return nil, err
+		} else if end, ok := tok.(xml.EndElement); ok && end.Name.Local == start.Name.Local {
+			return msgs, nil
+		} else if trnStart, ok := tok.(xml.StartElement); ok {
+			switch trnStart.Name.Local {
+			case "CCSTMTTRNRS":
+				var msg CCStatementResponse
+				if err := d.DecodeElement(&msg, &trnStart); err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, &msg)
+			default:
+				return nil, &UnsupportedMessageSetError{MessageSet: trnStart.Name.Local}
+			}
+		} else {
+			return nil, &UnexpectedElementError{Expected: "start or end element"}
+		}
+	}
+}
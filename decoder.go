@@ -0,0 +1,596 @@
+package ofxgo
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// ResponseDecoder parses an OFX response one message at a time, rather
+// than materializing the entire document into a Response up front. This
+// keeps memory use bounded while working through investment statements
+// with tens of thousands of INVTRANLIST transactions or SECLISTMSGSRSV1
+// responses listing thousands of securities: those are the two shapes Next
+// decodes incrementally (see its doc comment for exactly what it yields
+// for each) rather than with a single DecodeElement call.
+//
+// Like ParseResponse, it autodetects SGML vs XML and is lenient about the
+// input format. After NewResponseDecoder returns successfully, Version and
+// Signon are already populated (the SIGNONMSGSRSV1 is mandatory and always
+// comes first), and repeated calls to Next return the messages that follow
+// it, one at a time, until io.EOF.
+type ResponseDecoder struct {
+	Version string
+	Signon  SignonResponse
+
+	decoder *xml.Decoder
+	pending []Message
+	done    bool
+
+	// streamSetName is set while Next is incrementally decoding the body of
+	// a SECLISTMSGSRSV1 or INVSTMTMSGSRSV1, so the matching nextXxx method
+	// resumes where it left off on the next call, instead of the top-level
+	// token loop.
+	streamSetName string
+
+	// secListEntered tracks whether nextSecurity has already consumed the
+	// opening tag of a SECLISTMSGSRSV1's single SECLISTRS.
+	secListEntered bool
+
+	// invStmtPhase and invStmtPeek track progress through an
+	// INVSTMTMSGSRSV1's INVSTMTTRNRS messages: header fields first, then
+	// each INVTRANLIST transaction individually, then the positions/balance
+	// that follow it. invStmtPeek holds a token nextInvStmtHeader already
+	// read past the header (because INVTRANLIST is optional) that the
+	// summary phase needs to see first.
+	invStmtPhase int
+	invStmtPeek  xml.Token
+}
+
+const (
+	invStmtNeedHeader = iota
+	invStmtInTransactions
+	invStmtNeedSummary
+)
+
+// NewResponseDecoder parses the OFX headers and SIGNONMSGSRSV1 from reader,
+// returning a ResponseDecoder positioned to decode the messages that follow
+// via Next.
+func NewResponseDecoder(reader io.Reader) (*ResponseDecoder, error) {
+	var d ResponseDecoder
+
+	r := bufio.NewReaderSize(reader, guessVersionCheckBytes)
+	xmlVersion, err := guessVersion(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !xmlVersion {
+		version, err := readSGMLHeaderVersion(r)
+		if err != nil {
+			return nil, err
+		}
+		d.Version = version
+	}
+
+	var xmlReader io.Reader = r
+	if !xmlVersion {
+		xmlReader = newSGMLToXMLReader(r)
+	}
+	decoder := xml.NewDecoder(xmlReader)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	d.decoder = decoder
+
+	if xmlVersion {
+		version, err := readXMLHeaderVersion(decoder)
+		if err != nil {
+			return nil, err
+		}
+		d.Version = version
+	}
+
+	tok, err := nextNonWhitespaceToken(decoder)
+	if err != nil {
+		return nil, err
+	} else if ofxStart, ok := tok.(xml.StartElement); !ok || ofxStart.Name.Local != "OFX" {
+		return nil, &UnexpectedElementError{Expected: "opening OFX xml element"}
+	}
+
+	tok, err = nextNonWhitespaceToken(decoder)
+	if err != nil {
+		return nil, err
+	} else if signonStart, ok := tok.(xml.StartElement); ok && signonStart.Name.Local == "SIGNONMSGSRSV1" {
+		if err := decoder.Decode(&d.Signon); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, &UnexpectedElementError{Expected: "opening SIGNONMSGSRSV1 xml element"}
+	}
+
+	tok, err = nextNonWhitespaceToken(decoder)
+	if err != nil {
+		return nil, err
+	} else if signonEnd, ok := tok.(xml.EndElement); !ok || signonEnd.Name.Local != "SIGNONMSGSRSV1" {
+		return nil, &UnexpectedElementError{Expected: "closing SIGNONMSGSRSV1 xml element"}
+	}
+	if ok, err := d.Signon.Valid(); !ok {
+		return nil, err
+	}
+	if d.Signon.Status.Code != 0 {
+		return nil, &StatusError{Status: d.Signon.Status}
+	}
+
+	return &d, nil
+}
+
+// Next returns the next Message in the response, or io.EOF once the
+// closing OFX element is reached.
+//
+// For most message sets, a Message is a whole response (a
+// StatementResponse, a CCStatementResponse, a ProfileResponse, etc.), read
+// and returned in one shot, same as ParseResponse would produce.
+//
+// SECLISTMSGSRSV1 and INVSTMTTRNRS are the exceptions: Next decodes them
+// incrementally so a single enormous SECLISTRS or INVTRANLIST is never
+// held in memory all at once.
+//
+//   - For SECLISTMSGSRSV1, Next returns each security (a *StockInfo or
+//     *MFInfo) as its own Message, instead of one *SecListResponse holding
+//     all of them.
+//   - For an INVSTMTTRNRS, Next first returns an *InvStatementHeader (its
+//     TRNUID/STATUS/CLTCOOKIE/CURDEF/INVACCTFROM), then each
+//     InvTransaction in its INVTRANLIST individually, and finally an
+//     *InvStatementSummary carrying its positions and balance.
+func (d *ResponseDecoder) Next() (Message, error) {
+	for len(d.pending) == 0 {
+		if d.done {
+			return nil, io.EOF
+		}
+
+		if d.streamSetName != "" {
+			msg, more, err := d.nextStreamed()
+			if err != nil {
+				return nil, err
+			}
+			if !more {
+				d.streamSetName = ""
+			}
+			if msg != nil {
+				d.pending = []Message{msg}
+			}
+			continue
+		}
+
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			if t.Name.Local != "OFX" {
+				return nil, &UnexpectedElementError{Expected: "closing OFX xml element", Found: t.Name.Local}
+			}
+			d.done = true
+			return nil, io.EOF
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "SECLISTMSGSRSV1", "INVSTMTMSGSRSV1":
+				d.streamSetName = t.Name.Local
+			default:
+				_, msgs, err := decodeMessageSet(d.decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				d.pending = msgs
+			}
+		default:
+			return nil, &UnexpectedElementError{Expected: "start or end element"}
+		}
+	}
+
+	msg := d.pending[0]
+	d.pending = d.pending[1:]
+	return msg, nil
+}
+
+// nextStreamed dispatches to the nextXxx method that resumes decoding
+// whichever message set d.streamSetName names.
+func (d *ResponseDecoder) nextStreamed() (Message, bool, error) {
+	switch d.streamSetName {
+	case "SECLISTMSGSRSV1":
+		return d.nextSecurity()
+	case "INVSTMTMSGSRSV1":
+		return d.nextInvStmtMessage()
+	default:
+		return nil, false, &UnexpectedElementError{Expected: "a streamable message set"}
+	}
+}
+
+// nextSecurity returns the next security inside a SECLISTMSGSRSV1's
+// SECLISTRS, or (nil, false, nil) once the whole message set (including its
+// closing tag) has been consumed.
+func (d *ResponseDecoder) nextSecurity() (Message, bool, error) {
+	if !d.secListEntered {
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, false, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "SECLISTRS" {
+			return nil, false, &UnexpectedElementError{Expected: "opening SECLISTRS xml element"}
+		}
+		d.secListEntered = true
+	}
+
+	tok, err := nextNonWhitespaceToken(d.decoder)
+	if err != nil {
+		return nil, false, err
+	}
+	if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "SECLISTRS" {
+		d.secListEntered = false
+		closeTok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, false, err
+		}
+		if e, ok := closeTok.(xml.EndElement); !ok || e.Name.Local != "SECLISTMSGSRSV1" {
+			return nil, false, &UnexpectedElementError{Expected: "closing SECLISTMSGSRSV1 xml element"}
+		}
+		return nil, false, nil
+	}
+
+	s, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, false, &UnexpectedElementError{Expected: "start or end element inside SECLISTRS"}
+	}
+	switch s.Name.Local {
+	case "STOCKINFO":
+		var info StockInfo
+		if err := d.decoder.DecodeElement(&info, &s); err != nil {
+			return nil, false, err
+		}
+		return &info, true, nil
+	case "MFINFO":
+		var info MFInfo
+		if err := d.decoder.DecodeElement(&info, &s); err != nil {
+			return nil, false, err
+		}
+		return &info, true, nil
+	default:
+		return nil, false, &UnsupportedMessageSetError{MessageSet: s.Name.Local}
+	}
+}
+
+// InvStatementHeader carries the TRNUID/STATUS/CLTCOOKIE/CURDEF/INVACCTFROM
+// fields of an INVSTMTTRNRS. ResponseDecoder.Next returns one of these
+// before the individual InvTransactions in its INVTRANLIST, and before the
+// *InvStatementSummary that follows them.
+type InvStatementHeader struct {
+	TrnUID      String
+	Status      Status
+	CltCookie   String
+	CurDef      String
+	InvAcctFrom InvAcctFrom
+}
+
+func (h *InvStatementHeader) Name() string {
+	return "INVSTMTTRNRS"
+}
+
+func (h *InvStatementHeader) Valid() (bool, error) {
+	if len(h.TrnUID) == 0 {
+		return false, errors.New("InvStatementHeader: TrnUID unset")
+	}
+	return true, nil
+}
+
+// InvStatementSummary carries the positions and balance that follow an
+// INVSTMTTRNRS's INVTRANLIST. ResponseDecoder.Next returns one of these
+// once it has delivered every transaction in that statement.
+type InvStatementSummary struct {
+	StockPositions  []InvPosition
+	MFPositions     []InvPosition
+	BondPositions   []InvPosition
+	OptionPositions []InvPosition
+	OtherPositions  []InvPosition
+	InvBalance      *InvBalance
+}
+
+func (s *InvStatementSummary) Name() string {
+	return "INVSTMTTRNRS positions/balance"
+}
+
+func (s *InvStatementSummary) Valid() (bool, error) {
+	return true, nil
+}
+
+// nextInvStmtMessage dispatches to whichever phase of an INVSTMTMSGSRSV1
+// decoding is in progress.
+func (d *ResponseDecoder) nextInvStmtMessage() (Message, bool, error) {
+	switch d.invStmtPhase {
+	case invStmtInTransactions:
+		return d.nextInvTransaction()
+	case invStmtNeedSummary:
+		return d.nextInvStmtSummary()
+	default:
+		return d.nextInvStmtHeader()
+	}
+}
+
+// nextInvStmtHeader reads up to (and including) an INVSTMTTRNRS's CURDEF
+// and INVACCTFROM, returning them as an *InvStatementHeader, or (nil,
+// false, nil) once the enclosing INVSTMTMSGSRSV1 has no more statements.
+func (d *ResponseDecoder) nextInvStmtHeader() (Message, bool, error) {
+	tok, err := nextNonWhitespaceToken(d.decoder)
+	if err != nil {
+		return nil, false, err
+	}
+	if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "INVSTMTMSGSRSV1" {
+		return nil, false, nil
+	}
+	start, ok := tok.(xml.StartElement)
+	if !ok || start.Name.Local != "INVSTMTTRNRS" {
+		return nil, false, &UnexpectedElementError{Expected: "opening INVSTMTTRNRS xml element"}
+	}
+
+	var header InvStatementHeader
+	for {
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, false, err
+		}
+		s, ok := tok.(xml.StartElement)
+		if !ok {
+			return nil, false, &UnexpectedElementError{Expected: "start element inside INVSTMTTRNRS"}
+		}
+		switch s.Name.Local {
+		case "TRNUID":
+			if err := d.decoder.DecodeElement(&header.TrnUID, &s); err != nil {
+				return nil, false, err
+			}
+		case "STATUS":
+			if err := d.decoder.DecodeElement(&header.Status, &s); err != nil {
+				return nil, false, err
+			}
+		case "CLTCOOKIE":
+			if err := d.decoder.DecodeElement(&header.CltCookie, &s); err != nil {
+				return nil, false, err
+			}
+		case "INVSTMTRS":
+			hasTranList, err := d.decodeInvStmtRsHeader(&header)
+			if err != nil {
+				return nil, false, err
+			}
+			if hasTranList {
+				d.invStmtPhase = invStmtInTransactions
+			} else {
+				d.invStmtPhase = invStmtNeedSummary
+			}
+			return &header, true, nil
+		default:
+			return nil, false, &UnexpectedElementError{Expected: "TRNUID, STATUS, CLTCOOKIE, or INVSTMTRS", Found: s.Name.Local}
+		}
+	}
+}
+
+// decodeInvStmtRsHeader reads an INVSTMTRS's leading fields (DTASOF,
+// CURDEF, INVACCTFROM) into header, stopping as soon as it knows whether an
+// INVTRANLIST follows. If it doesn't (INVPOSLIST, INVBAL, or the closing
+// INVSTMTRS tag come first instead), the token it read to find that out is
+// stashed in d.invStmtPeek for nextInvStmtSummary to pick up.
+func (d *ResponseDecoder) decodeInvStmtRsHeader(header *InvStatementHeader) (bool, error) {
+	for {
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return false, err
+		}
+		if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "INVSTMTRS" {
+			d.invStmtPeek = tok
+			return false, nil
+		}
+		s, ok := tok.(xml.StartElement)
+		if !ok {
+			return false, &UnexpectedElementError{Expected: "start or end element inside INVSTMTRS"}
+		}
+		switch s.Name.Local {
+		case "DTASOF", "MKTGINFO":
+			if err := d.decoder.Skip(); err != nil {
+				return false, err
+			}
+		case "CURDEF":
+			if err := d.decoder.DecodeElement(&header.CurDef, &s); err != nil {
+				return false, err
+			}
+		case "INVACCTFROM":
+			if err := d.decoder.DecodeElement(&header.InvAcctFrom, &s); err != nil {
+				return false, err
+			}
+		case "INVTRANLIST":
+			if err := d.skipInvTranListDates(); err != nil {
+				return false, err
+			}
+			return true, nil
+		case "INVPOSLIST", "INVBAL":
+			d.invStmtPeek = tok
+			return false, nil
+		default:
+			return false, &UnexpectedElementError{Expected: "INVSTMTRS child element", Found: s.Name.Local}
+		}
+	}
+}
+
+// skipInvTranListDates consumes an INVTRANLIST's mandatory leading
+// DTSTART/DTEND, leaving the decoder positioned at its first transaction
+// (or its closing tag, if it's empty).
+func (d *ResponseDecoder) skipInvTranListDates() error {
+	for _, want := range []string{"DTSTART", "DTEND"} {
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return err
+		}
+		s, ok := tok.(xml.StartElement)
+		if !ok || s.Name.Local != want {
+			return &UnexpectedElementError{Expected: want}
+		}
+		if err := d.decoder.Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextInvTransaction returns the next transaction inside the current
+// statement's INVTRANLIST, or moves on to decoding the statement's
+// positions/balance once INVTRANLIST closes.
+func (d *ResponseDecoder) nextInvTransaction() (Message, bool, error) {
+	tok, err := nextNonWhitespaceToken(d.decoder)
+	if err != nil {
+		return nil, false, err
+	}
+	if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "INVTRANLIST" {
+		d.invStmtPhase = invStmtNeedSummary
+		return d.nextInvStmtSummary()
+	}
+
+	s, ok := tok.(xml.StartElement)
+	if !ok {
+		return nil, false, &UnexpectedElementError{Expected: "start or end element inside INVTRANLIST"}
+	}
+
+	var txn InvTransaction
+	switch s.Name.Local {
+	case "BUYSTOCK":
+		var t BuyStock
+		if err := d.decoder.DecodeElement(&t, &s); err != nil {
+			return nil, false, err
+		}
+		txn = &t
+	case "SELLSTOCK":
+		var t SellStock
+		if err := d.decoder.DecodeElement(&t, &s); err != nil {
+			return nil, false, err
+		}
+		txn = &t
+	case "INCOME":
+		var t Income
+		if err := d.decoder.DecodeElement(&t, &s); err != nil {
+			return nil, false, err
+		}
+		txn = &t
+	case "INVBANKTRAN":
+		var t InvBankTran
+		if err := d.decoder.DecodeElement(&t, &s); err != nil {
+			return nil, false, err
+		}
+		txn = &t
+	default:
+		return nil, false, &UnsupportedInvTransactionError{TransactionType: s.Name.Local}
+	}
+	return txn, true, nil
+}
+
+// invPosWrapper decodes a single POSSTOCK/POSMF/POSBOND/POSOPT/POSOTHER
+// element down to the INVPOS fields common to all of them.
+type invPosWrapper struct {
+	InvPos InvPosition `xml:"INVPOS"`
+}
+
+// decodeInvPosList reads an INVSTMTRS's INVPOSLIST into summary, sorting
+// each position into the field matching its POSxxx wrapper element.
+func (d *ResponseDecoder) decodeInvPosList(summary *InvStatementSummary) error {
+	for {
+		tok, err := nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return err
+		}
+		if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "INVPOSLIST" {
+			return nil
+		}
+		s, ok := tok.(xml.StartElement)
+		if !ok {
+			return &UnexpectedElementError{Expected: "start or end element inside INVPOSLIST"}
+		}
+		var pos invPosWrapper
+		if err := d.decoder.DecodeElement(&pos, &s); err != nil {
+			return err
+		}
+		switch s.Name.Local {
+		case "POSSTOCK":
+			summary.StockPositions = append(summary.StockPositions, pos.InvPos)
+		case "POSMF":
+			summary.MFPositions = append(summary.MFPositions, pos.InvPos)
+		case "POSBOND":
+			summary.BondPositions = append(summary.BondPositions, pos.InvPos)
+		case "POSOPT":
+			summary.OptionPositions = append(summary.OptionPositions, pos.InvPos)
+		case "POSOTHER":
+			summary.OtherPositions = append(summary.OtherPositions, pos.InvPos)
+		default:
+			return &UnexpectedElementError{Expected: "POSSTOCK, POSMF, POSBOND, POSOPT, or POSOTHER", Found: s.Name.Local}
+		}
+	}
+}
+
+// nextInvStmtSummary reads whatever is left of the current INVSTMTRS
+// (INVPOSLIST, INVBAL) plus the closing INVSTMTTRNRS tag, returning them as
+// an *InvStatementSummary before returning to invStmtNeedHeader to look for
+// another statement (or the closing INVSTMTMSGSRSV1 tag).
+func (d *ResponseDecoder) nextInvStmtSummary() (Message, bool, error) {
+	var summary InvStatementSummary
+
+	tok := d.invStmtPeek
+	d.invStmtPeek = nil
+	var err error
+	if tok == nil {
+		tok, err = nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for {
+		if end, ok := tok.(xml.EndElement); ok && end.Name.Local == "INVSTMTRS" {
+			break
+		}
+		s, ok := tok.(xml.StartElement)
+		if !ok {
+			return nil, false, &UnexpectedElementError{Expected: "start or end element inside INVSTMTRS"}
+		}
+		switch s.Name.Local {
+		case "INVPOSLIST":
+			if err := d.decodeInvPosList(&summary); err != nil {
+				return nil, false, err
+			}
+		case "INVBAL":
+			var bal InvBalance
+			if err := d.decoder.DecodeElement(&bal, &s); err != nil {
+				return nil, false, err
+			}
+			summary.InvBalance = &bal
+		case "MKTGINFO":
+			if err := d.decoder.Skip(); err != nil {
+				return nil, false, err
+			}
+		default:
+			return nil, false, &UnexpectedElementError{Expected: "INVSTMTRS child element", Found: s.Name.Local}
+		}
+
+		tok, err = nextNonWhitespaceToken(d.decoder)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	end, err := nextNonWhitespaceToken(d.decoder)
+	if err != nil {
+		return nil, false, err
+	}
+	if e, ok := end.(xml.EndElement); !ok || e.Name.Local != "INVSTMTTRNRS" {
+		return nil, false, &UnexpectedElementError{Expected: "closing INVSTMTTRNRS xml element"}
+	}
+
+	d.invStmtPhase = invStmtNeedHeader
+	return &summary, true, nil
+}
@@ -0,0 +1,241 @@
+package ofxgo
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// invStmtDoc is a minimal OFX document with two BUYSTOCK transactions and a
+// mix of stock/mutual-fund positions, used to check that ResponseDecoder
+// yields the investment statement piecewise rather than all at once. It uses
+// an OFX SGML header (leaf elements closed implicitly, as real FIs send
+// them) rather than XML, since the body below relies on that leniency.
+const invStmtDoc = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS><CODE>0<SEVERITY>INFO</STATUS>
+<DTSERVER>20060115000000
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<INVSTMTMSGSRSV1>
+<INVSTMTTRNRS>
+<TRNUID>1001
+<STATUS><CODE>0<SEVERITY>INFO</STATUS>
+<INVSTMTRS>
+<CURDEF>USD
+<INVACCTFROM><BROKERID>example.com<ACCTID>12345</INVACCTFROM>
+<INVTRANLIST>
+<DTSTART>20060101000000
+<DTEND>20060131000000
+<BUYSTOCK>
+<INVBUY>
+<INVTRAN><FITID>1<DTTRADE>20060105000000</INVTRAN>
+<SECID><UNIQUEID>123456789<UNIQUEIDTYPE>CUSIP</SECID>
+<UNITS>10
+<UNITPRICE>100
+<TOTAL>-1000
+<SUBACCTSEC>CASH
+<SUBACCTFUND>CASH
+</INVBUY>
+<BUYTYPE>BUY
+</BUYSTOCK>
+<BUYSTOCK>
+<INVBUY>
+<INVTRAN><FITID>2<DTTRADE>20060110000000</INVTRAN>
+<SECID><UNIQUEID>987654321<UNIQUEIDTYPE>CUSIP</SECID>
+<UNITS>5
+<UNITPRICE>50
+<TOTAL>-250
+<SUBACCTSEC>CASH
+<SUBACCTFUND>CASH
+</INVBUY>
+<BUYTYPE>BUY
+</BUYSTOCK>
+</INVTRANLIST>
+<INVPOSLIST>
+<POSSTOCK>
+<INVPOS>
+<SECID><UNIQUEID>123456789<UNIQUEIDTYPE>CUSIP</SECID>
+<HELDINACCT>CASH
+<POSTYPE>LONG
+<UNITS>10
+<UNITPRICE>105
+<MKTVAL>1050
+<DTPRICEASOF>20060131000000
+</INVPOS>
+</POSSTOCK>
+<POSMF>
+<INVPOS>
+<SECID><UNIQUEID>555555555<UNIQUEIDTYPE>CUSIP</SECID>
+<HELDINACCT>CASH
+<POSTYPE>LONG
+<UNITS>20
+<UNITPRICE>25
+<MKTVAL>500
+<DTPRICEASOF>20060131000000
+</INVPOS>
+</POSMF>
+</INVPOSLIST>
+<INVBAL>
+<AVAILCASH>1234.56
+<MARGINBALANCE>0
+<SHORTBALANCE>0
+</INVBAL>
+</INVSTMTRS>
+</INVSTMTTRNRS>
+</INVSTMTMSGSRSV1>
+</OFX>
+`
+
+func TestResponseDecoderStreamsInvStatementPiecewise(t *testing.T) {
+	d, err := NewResponseDecoder(strings.NewReader(invStmtDoc))
+	if err != nil {
+		t.Fatalf("NewResponseDecoder: %v", err)
+	}
+
+	msg, err := d.Next()
+	if err != nil {
+		t.Fatalf("Next (header): %v", err)
+	}
+	header, ok := msg.(*InvStatementHeader)
+	if !ok {
+		t.Fatalf("expected *InvStatementHeader, got %T", msg)
+	}
+	if header.TrnUID != "1001" {
+		t.Errorf("header.TrnUID = %q, want %q", header.TrnUID, "1001")
+	}
+	if header.CurDef != "USD" {
+		t.Errorf("header.CurDef = %q, want %q", header.CurDef, "USD")
+	}
+
+	var txns []InvTransaction
+	for {
+		msg, err := d.Next()
+		if err != nil {
+			t.Fatalf("Next (transaction): %v", err)
+		}
+		txn, ok := msg.(InvTransaction)
+		if !ok {
+			if summary, ok := msg.(*InvStatementSummary); ok {
+				if len(summary.StockPositions) != 1 {
+					t.Errorf("len(StockPositions) = %d, want 1", len(summary.StockPositions))
+				}
+				if len(summary.MFPositions) != 1 {
+					t.Errorf("len(MFPositions) = %d, want 1", len(summary.MFPositions))
+				}
+				if summary.InvBalance == nil {
+					t.Fatalf("InvBalance is nil")
+				}
+				break
+			}
+			t.Fatalf("expected an InvTransaction or *InvStatementSummary, got %T", msg)
+		}
+		txns = append(txns, txn)
+	}
+
+	if len(txns) != 2 {
+		t.Fatalf("len(txns) = %d, want 2", len(txns))
+	}
+	if txns[0].Name() != "BUYSTOCK" || txns[1].Name() != "BUYSTOCK" {
+		t.Errorf("unexpected transaction types: %s, %s", txns[0].Name(), txns[1].Name())
+	}
+
+	if _, err := d.Next(); err != io.EOF {
+		t.Fatalf("Next (final): got err %v, want io.EOF", err)
+	}
+}
+
+// secListDoc is a minimal OFX document with a SECLISTMSGSRSV1 holding one
+// stock and one mutual fund, used to check that ResponseDecoder yields the
+// securities list piecewise rather than all at once.
+const secListDoc = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS><CODE>0<SEVERITY>INFO</STATUS>
+<DTSERVER>20060115000000
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<SECLISTMSGSRSV1>
+<SECLISTRS>
+<STOCKINFO>
+<SECINFO><SECID><UNIQUEID>111111111<UNIQUEIDTYPE>CUSIP</SECID>
+<SECNAME>Example Corp
+<TICKER>EX
+<UNITPRICE>10.5
+</SECINFO>
+</STOCKINFO>
+<MFINFO>
+<SECINFO><SECID><UNIQUEID>222222222<UNIQUEIDTYPE>CUSIP</SECID>
+<SECNAME>Example Fund
+<UNITPRICE>20
+</SECINFO>
+<MFTYPE>OPENEND
+</MFINFO>
+</SECLISTRS>
+</SECLISTMSGSRSV1>
+</OFX>
+`
+
+func TestResponseDecoderStreamsSecListPiecewise(t *testing.T) {
+	d, err := NewResponseDecoder(strings.NewReader(secListDoc))
+	if err != nil {
+		t.Fatalf("NewResponseDecoder: %v", err)
+	}
+
+	msg, err := d.Next()
+	if err != nil {
+		t.Fatalf("Next (stock): %v", err)
+	}
+	stock, ok := msg.(*StockInfo)
+	if !ok {
+		t.Fatalf("expected *StockInfo, got %T", msg)
+	}
+	if stock.SecId.UniqueId != "111111111" {
+		t.Errorf("stock.SecId.UniqueId = %q, want %q", stock.SecId.UniqueId, "111111111")
+	}
+	if stock.Ticker != "EX" {
+		t.Errorf("stock.Ticker = %q, want %q", stock.Ticker, "EX")
+	}
+
+	msg, err = d.Next()
+	if err != nil {
+		t.Fatalf("Next (mf): %v", err)
+	}
+	mf, ok := msg.(*MFInfo)
+	if !ok {
+		t.Fatalf("expected *MFInfo, got %T", msg)
+	}
+	if mf.SecId.UniqueId != "222222222" {
+		t.Errorf("mf.SecId.UniqueId = %q, want %q", mf.SecId.UniqueId, "222222222")
+	}
+	if mf.MFType != "OPENEND" {
+		t.Errorf("mf.MFType = %q, want %q", mf.MFType, "OPENEND")
+	}
+
+	if _, err := d.Next(); err != io.EOF {
+		t.Fatalf("Next (final): got err %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,92 @@
+package ofxgo
+
+import "fmt"
+
+// HeaderError is returned when an OFX SGML or XML header is missing,
+// malformed, or contains a value ofxgo doesn't understand (e.g. a
+// SECURITY or COMPRESSION setting other than NONE).
+type HeaderError struct {
+	Header  string // The header field in question, e.g. "VERSION"
+	Value   string // The value found for that header, if any
+	Message string
+}
+
+func (e *HeaderError) Error() string {
+	if e.Header == "" {
+		return "OFX header error: " + e.Message
+	}
+	return fmt.Sprintf("OFX header error: %s (%s: %q)", e.Message, e.Header, e.Value)
+}
+
+// UnsupportedVersionError is returned when a Request specifies an OFX
+// VERSION ofxgo doesn't know how to marshal. An unrecognized VERSION in a
+// parsed response's header is reported as a *HeaderError instead.
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return e.Version + " is not a valid OFX version string"
+}
+
+// UnsupportedMessageSetError is returned when ParseResponse encounters a
+// message set element it doesn't know how to decode.
+type UnsupportedMessageSetError struct {
+	MessageSet string // The offending element name, e.g. "LOANMSGSRSV1"
+}
+
+func (e *UnsupportedMessageSetError) Error() string {
+	return "Unsupported message set: " + e.MessageSet
+}
+
+// UnsupportedInvTransactionError is returned when an INVTRANLIST contains a
+// transaction type ofxgo doesn't know how to decode (e.g. REINVEST or
+// BUYOPT).
+type UnsupportedInvTransactionError struct {
+	TransactionType string // The offending element name, e.g. "REINVEST"
+}
+
+func (e *UnsupportedInvTransactionError) Error() string {
+	return "Unsupported investment transaction type: " + e.TransactionType
+}
+
+// StatusError wraps an OFX <STATUS> aggregate whose Code indicates
+// something other than success (0), most commonly found in SIGNONMSGSRSV1.
+//
+// Callers can use errors.As to recover the underlying Status (and thus its
+// Code/Severity/Message), or errors.Is with another *StatusError to check
+// for a specific status code, e.g.:
+//
+//	if errors.Is(err, &ofxgo.StatusError{Status: ofxgo.Status{Code: 15500}}) {
+//		// prompt the user to re-enter their password
+//	}
+type StatusError struct {
+	Status Status
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("OFX status %v (%s): %s", e.Status.Code, e.Status.Severity, e.Status.Message)
+}
+
+// Is reports whether target is a *StatusError with the same Code, allowing
+// callers to match against well-known status codes without comparing
+// pointers.
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	if !ok {
+		return false
+	}
+	return e.Status.Code == t.Status.Code
+}
+
+// UnexpectedElementError is returned when ParseResponse finds a token in
+// the XML stream other than the one it expected at that point in the
+// document (e.g. a missing opening or closing tag).
+type UnexpectedElementError struct {
+	Expected string // The element ofxgo expected to find, e.g. "SIGNONMSGSRSV1"
+	Found    string // What was found instead
+}
+
+func (e *UnexpectedElementError) Error() string {
+	return "expected " + e.Expected + ", found " + e.Found
+}
@@ -0,0 +1,331 @@
+package ofxgo
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// InvAcctFrom identifies the investment account a statement request or
+// response pertains to.
+type InvAcctFrom struct {
+	BrokerId String `xml:"BROKERID"`
+	AcctId   String `xml:"ACCTID"`
+}
+
+func (a *InvAcctFrom) Valid() (bool, error) {
+	if len(a.BrokerId) == 0 {
+		return false, errors.New("InvAcctFrom: BROKERID unset")
+	}
+	if len(a.AcctId) == 0 {
+		return false, errors.New("InvAcctFrom: ACCTID unset")
+	}
+	return true, nil
+}
+
+// InvStatementRequest represents an INVSTMTTRNRQ, requesting the
+// transactions, positions, and balances for a single investment account.
+type InvStatementRequest struct {
+	XMLName   xml.Name `xml:"INVSTMTTRNRQ"`
+	TrnUID    String   `xml:"TRNUID"`
+	CltCookie String   `xml:"CLTCOOKIE,omitempty"`
+
+	InvAcctFrom InvAcctFrom `xml:"INVSTMTRQ>INVACCTFROM"`
+	DtStart     *Date       `xml:"INVSTMTRQ>INCTRAN>DTSTART,omitempty"`
+	DtEnd       *Date       `xml:"INVSTMTRQ>INCTRAN>DTEND,omitempty"`
+	Include     bool        `xml:"INVSTMTRQ>INCTRAN>INCLUDE"`
+	IncOO       bool        `xml:"INVSTMTRQ>INCOO"`          // Include open orders
+	IncPos      bool        `xml:"INVSTMTRQ>INCPOS>INCLUDE"` // Include current positions
+	IncBalance  bool        `xml:"INVSTMTRQ>INCBAL"`         // Include current balances
+}
+
+func (r *InvStatementRequest) Name() string {
+	return "INVSTMTTRNRQ"
+}
+
+func (r *InvStatementRequest) Valid() (bool, error) {
+	if len(r.TrnUID) == 0 {
+		return false, errors.New("InvStatementRequest: TrnUID unset")
+	}
+	return r.InvAcctFrom.Valid()
+}
+
+// InvStatementResponse represents an INVSTMTTRNRS, the reply to an
+// InvStatementRequest. Transactions, positions, and balances are each
+// optional, depending on what was requested and what the FI supports.
+type InvStatementResponse struct {
+	XMLName   xml.Name `xml:"INVSTMTTRNRS"`
+	TrnUID    String   `xml:"TRNUID"`
+	Status    Status   `xml:"STATUS"`
+	CltCookie String   `xml:"CLTCOOKIE,omitempty"`
+
+	CurDef      String       `xml:"INVSTMTRS>CURDEF"`
+	InvAcctFrom InvAcctFrom  `xml:"INVSTMTRS>INVACCTFROM"`
+	InvTranList *InvTranList `xml:"INVSTMTRS>INVTRANLIST,omitempty"`
+
+	// Positions are split out by security type because that's how OFX
+	// tags them (POSSTOCK, POSMF, POSBOND, POSOPT, POSOTHER); use
+	// InvPositions for all of them together regardless of type.
+	StockPositions  []InvPosition `xml:"INVSTMTRS>INVPOSLIST>POSSTOCK>INVPOS,omitempty"`
+	MFPositions     []InvPosition `xml:"INVSTMTRS>INVPOSLIST>POSMF>INVPOS,omitempty"`
+	BondPositions   []InvPosition `xml:"INVSTMTRS>INVPOSLIST>POSBOND>INVPOS,omitempty"`
+	OptionPositions []InvPosition `xml:"INVSTMTRS>INVPOSLIST>POSOPT>INVPOS,omitempty"`
+	OtherPositions  []InvPosition `xml:"INVSTMTRS>INVPOSLIST>POSOTHER>INVPOS,omitempty"`
+
+	InvBalance *InvBalance `xml:"INVSTMTRS>INVBAL,omitempty"`
+}
+
+func (is *InvStatementResponse) Name() string {
+	return "INVSTMTTRNRS"
+}
+
+func (is *InvStatementResponse) Valid() (bool, error) {
+	if len(is.TrnUID) == 0 {
+		return false, errors.New("InvStatementResponse: TrnUID unset")
+	}
+	return true, nil
+}
+
+// InvPositions returns every held position in the statement, regardless of
+// security type (stock, mutual fund, bond, option, or other). Most callers
+// that don't care about the distinction should use this instead of the
+// individual StockPositions/MFPositions/... fields.
+func (is *InvStatementResponse) InvPositions() []InvPosition {
+	all := make([]InvPosition, 0, len(is.StockPositions)+len(is.MFPositions)+len(is.BondPositions)+len(is.OptionPositions)+len(is.OtherPositions))
+	all = append(all, is.StockPositions...)
+	all = append(all, is.MFPositions...)
+	all = append(all, is.BondPositions...)
+	all = append(all, is.OptionPositions...)
+	all = append(all, is.OtherPositions...)
+	return all
+}
+
+// InvTransaction is implemented by every investment transaction type found
+// in an INVTRANLIST (BuyStock, SellStock, Income, InvBankTran, etc.), and
+// by extension satisfies Message so ResponseDecoder can hand individual
+// transactions to callers the same way it does whole messages.
+type InvTransaction interface {
+	Message
+}
+
+// InvTran carries the fields common to every investment transaction type
+// (the INVTRAN aggregate nested inside each one).
+type InvTran struct {
+	FiTId    String `xml:"FITID"`
+	SrvrTId  String `xml:"SRVRTID,omitempty"`
+	DtTrade  Date   `xml:"DTTRADE"`
+	DtSettle *Date  `xml:"DTSETTLE,omitempty"`
+	Memo     String `xml:"MEMO,omitempty"`
+}
+
+// BuyStock represents a BUYSTOCK, the purchase of a stock position.
+type BuyStock struct {
+	InvTran     InvTran `xml:"INVBUY>INVTRAN"`
+	SecId       SecId   `xml:"INVBUY>SECID"`
+	Units       Amount  `xml:"INVBUY>UNITS"`
+	UnitPrice   Amount  `xml:"INVBUY>UNITPRICE"`
+	Commission  Amount  `xml:"INVBUY>COMMISSION,omitempty"`
+	Total       Amount  `xml:"INVBUY>TOTAL"`
+	SubAcctSec  String  `xml:"INVBUY>SUBACCTSEC,omitempty"`
+	SubAcctFund String  `xml:"INVBUY>SUBACCTFUND,omitempty"`
+	BuyType     String  `xml:"BUYTYPE"`
+}
+
+func (t *BuyStock) Name() string {
+	return "BUYSTOCK"
+}
+
+func (t *BuyStock) Valid() (bool, error) {
+	if len(t.InvTran.FiTId) == 0 {
+		return false, errors.New("BuyStock: FITID unset")
+	}
+	return true, nil
+}
+
+// SellStock represents a SELLSTOCK, the sale of a stock position.
+type SellStock struct {
+	InvTran     InvTran `xml:"INVSELL>INVTRAN"`
+	SecId       SecId   `xml:"INVSELL>SECID"`
+	Units       Amount  `xml:"INVSELL>UNITS"`
+	UnitPrice   Amount  `xml:"INVSELL>UNITPRICE"`
+	Commission  Amount  `xml:"INVSELL>COMMISSION,omitempty"`
+	Total       Amount  `xml:"INVSELL>TOTAL"`
+	SubAcctSec  String  `xml:"INVSELL>SUBACCTSEC,omitempty"`
+	SubAcctFund String  `xml:"INVSELL>SUBACCTFUND,omitempty"`
+	SellType    String  `xml:"SELLTYPE"`
+}
+
+func (t *SellStock) Name() string {
+	return "SELLSTOCK"
+}
+
+func (t *SellStock) Valid() (bool, error) {
+	if len(t.InvTran.FiTId) == 0 {
+		return false, errors.New("SellStock: FITID unset")
+	}
+	return true, nil
+}
+
+// Income represents an INCOME transaction, recording a dividend, interest,
+// or other income payment into an investment account.
+type Income struct {
+	InvTran     InvTran `xml:"INVTRAN"`
+	SecId       SecId   `xml:"SECID"`
+	IncomeType  String  `xml:"INCOMETYPE"`
+	Total       Amount  `xml:"TOTAL"`
+	SubAcctSec  String  `xml:"SUBACCTSEC,omitempty"`
+	SubAcctFund String  `xml:"SUBACCTFUND,omitempty"`
+	TaxExempt   String  `xml:"TAXEXEMPT,omitempty"`
+}
+
+func (t *Income) Name() string {
+	return "INCOME"
+}
+
+func (t *Income) Valid() (bool, error) {
+	if len(t.InvTran.FiTId) == 0 {
+		return false, errors.New("Income: FITID unset")
+	}
+	return true, nil
+}
+
+// InvBankTran represents an INVBANKTRAN, a cash transaction (e.g. a
+// transfer or a fee) against an investment account's cash sub-account.
+type InvBankTran struct {
+	FiTId       String `xml:"STMTTRN>FITID"`
+	DtPosted    Date   `xml:"STMTTRN>DTPOSTED"`
+	TrnAmt      Amount `xml:"STMTTRN>TRNAMT"`
+	Memo        String `xml:"STMTTRN>MEMO,omitempty"`
+	SubAcctFund String `xml:"SUBACCTFUND,omitempty"`
+}
+
+func (t *InvBankTran) Name() string {
+	return "INVBANKTRAN"
+}
+
+func (t *InvBankTran) Valid() (bool, error) {
+	if len(t.FiTId) == 0 {
+		return false, errors.New("InvBankTran: FITID unset")
+	}
+	return true, nil
+}
+
+// InvTranList represents an INVTRANLIST, the list of investment
+// transactions (buys, sells, income, cash transfers, etc.) in an investment
+// statement response, in statement order.
+type InvTranList struct {
+	DtStart      Date             `xml:"DTSTART"`
+	DtEnd        Date             `xml:"DTEND"`
+	Transactions []InvTransaction `xml:"-"`
+}
+
+// UnmarshalXML decodes an INVTRANLIST by hand: unlike the other aggregates
+// in this package, it's a mixed sequence of differently-tagged transaction
+// elements (BUYSTOCK, SELLSTOCK, INCOME, INVBANKTRAN, ...) in statement
+// order, which plain encoding/xml struct tags can't express. Transaction
+// types OFX defines that ofxgo doesn't yet model (REINVEST, TRANSFER,
+// BUYOPT, etc.) fail decoding with *UnsupportedInvTransactionError rather
+// than being silently dropped.
+func (l *InvTranList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := nextNonWhitespaceToken(d)
+		if err != nil {
+			return err
+		} else if end, ok := tok.(xml.EndElement); ok && end.Name.Local == start.Name.Local {
+			return nil
+		} else if s, ok := tok.(xml.StartElement); ok {
+			switch s.Name.Local {
+			case "DTSTART":
+				if err := d.DecodeElement(&l.DtStart, &s); err != nil {
+					return err
+				}
+			case "DTEND":
+				if err := d.DecodeElement(&l.DtEnd, &s); err != nil {
+					return err
+				}
+			case "BUYSTOCK":
+				var t BuyStock
+				if err := d.DecodeElement(&t, &s); err != nil {
+					return err
+				}
+				l.Transactions = append(l.Transactions, &t)
+			case "SELLSTOCK":
+				var t SellStock
+				if err := d.DecodeElement(&t, &s); err != nil {
+					return err
+				}
+				l.Transactions = append(l.Transactions, &t)
+			case "INCOME":
+				var t Income
+				if err := d.DecodeElement(&t, &s); err != nil {
+					return err
+				}
+				l.Transactions = append(l.Transactions, &t)
+			case "INVBANKTRAN":
+				var t InvBankTran
+				if err := d.DecodeElement(&t, &s); err != nil {
+					return err
+				}
+				l.Transactions = append(l.Transactions, &t)
+			default:
+				return &UnsupportedInvTransactionError{TransactionType: s.Name.Local}
+			}
+		} else {
+			return &UnexpectedElementError{Expected: "start or end element"}
+		}
+	}
+}
+
+// InvPosition represents an INVPOS, a single held security position. It
+// carries the fields common to every position type (POSSTOCK, POSMF,
+// POSBOND, POSOPT, POSOTHER); see InvStatementResponse's per-type fields
+// for which kind of security a given position is in.
+type InvPosition struct {
+	SecId       SecId  `xml:"SECID"`
+	HeldInAcct  String `xml:"HELDINACCT"`
+	PosType     String `xml:"POSTYPE"`
+	Units       Amount `xml:"UNITS"`
+	UnitPrice   Amount `xml:"UNITPRICE"`
+	MktVal      Amount `xml:"MKTVAL"`
+	DtPriceAsOf Date   `xml:"DTPRICEASOF"`
+}
+
+// InvBalance represents an INVBAL, the cash and other balances associated
+// with an investment account.
+type InvBalance struct {
+	AvailCash     Amount `xml:"AVAILCASH"`
+	MarginBalance Amount `xml:"MARGINBALANCE"`
+	ShortBalance  Amount `xml:"SHORTBALANCE"`
+}
+
+// DecodeInvStmtMessageSet consumes an INVSTMTMSGSRSV1 element (and
+// everything inside it), returning the decoded INVSTMTTRNRS messages it
+// contains.
+//
+// This decodes each statement (including its INVTRANLIST) in one shot;
+// callers streaming a ResponseDecoder over a statement with a very large
+// INVTRANLIST should use ResponseDecoder.Next instead, which yields each
+// transaction individually rather than materializing them all here.
+func DecodeInvStmtMessageSet(d *xml.Decoder, start xml.StartElement) ([]Message, error) {
+	var msgs []Message
+	for {
+		tok, err := nextNonWhitespaceToken(d)
+		if err != nil {
+			return nil, err
+		} else if end, ok := tok.(xml.EndElement); ok && end.Name.Local == start.Name.Local {
+			return msgs, nil
+		} else if trnStart, ok := tok.(xml.StartElement); ok {
+			switch trnStart.Name.Local {
+			case "INVSTMTTRNRS":
+				var msg InvStatementResponse
+				if err := d.DecodeElement(&msg, &trnStart); err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, &msg)
+			default:
+				return nil, &UnsupportedMessageSetError{MessageSet: trnStart.Name.Local}
+			}
+		} else {
+			return nil, &UnexpectedElementError{Expected: "start or end element"}
+		}
+	}
+}
@@ -3,9 +3,11 @@ package ofxgo
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/xml"
 	"errors"
-	"github.com/golang/go/src/encoding/xml"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
@@ -20,6 +22,37 @@ type Client struct {
 
 	// Don't insert newlines or indentation when marshalling to SGML/XML
 	NoIndent bool
+
+	// Some FIs require their requests to use CRLF line endings rather than
+	// the bare LF ofxgo marshals by default
+	CarriageReturnNewLines bool
+
+	// HTTPClient is used to make the underlying HTTP request, allowing
+	// callers to set timeouts, proxies, TLS configuration, cookie jars,
+	// etc. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// RequestDump, if set, is called with the raw marshaled request and raw
+	// response bytes for every request made through this Client, to aid in
+	// debugging FI-specific quirks without needing to reimplement the
+	// transport.
+	RequestDump func(request, response []byte)
+
+	// CachedProfile, if set (typically by a prior call to Profile), is the
+	// last profile successfully fetched for the FI this Client talks to.
+	CachedProfile *ProfileResponse
+
+	// ProfileCacheDir overrides where Profile stores its on-disk cache of
+	// FI profiles, keyed by (org, fid). If empty, a per-user cache
+	// directory (as determined by os.UserCacheDir) is used.
+	ProfileCacheDir string
+
+	// cachedProfileVersion is the OFX SpecVersion the FI used when it
+	// replied to the PROFRQ that produced CachedProfile. OfxVersion falls
+	// back to it when SpecVersion isn't set explicitly, so a prior
+	// Profile call lets Request negotiate a version the FI is known to
+	// understand instead of guessing at the package default.
+	cachedProfileVersion string
 }
 
 var defaultClient Client
@@ -27,6 +60,8 @@ var defaultClient Client
 func (c *Client) OfxVersion() string {
 	if len(c.SpecVersion) > 0 {
 		return c.SpecVersion
+	} else if len(c.cachedProfileVersion) > 0 {
+		return c.cachedProfileVersion
 	} else {
 		return "203"
 	}
@@ -52,8 +87,26 @@ func (c *Client) IndentRequests() bool {
 	return !c.NoIndent
 }
 
-func RawRequest(URL string, r io.Reader) (*http.Response, error) {
-	response, err := http.Post(URL, "application/x-ofx", r)
+// httpClient returns the *http.Client to issue requests with, falling back
+// to http.DefaultClient if the caller hasn't configured one.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// RawRequestContext is identical to RawRequest, except that it allows the
+// caller to pass a context.Context to bound/cancel the underlying HTTP
+// request.
+func (c *Client) RawRequestContext(ctx context.Context, URL string, r io.Reader) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", URL, r)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-ofx")
+
+	response, err := c.httpClient().Do(request)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +118,21 @@ func RawRequest(URL string, r io.Reader) (*http.Response, error) {
 	return response, nil
 }
 
+// RawRequest sends a POST request with the given (already-marshaled) body
+// to URL, using the Client's configured HTTPClient (or http.DefaultClient,
+// if unset).
+func (c *Client) RawRequest(URL string, r io.Reader) (*http.Response, error) {
+	return c.RawRequestContext(context.Background(), URL, r)
+}
+
+// RawRequest sends a POST request with the given (already-marshaled) body
+// to URL, using http.DefaultClient. It's kept around for callers that don't
+// need per-Client transport configuration; prefer (*Client).RawRequest or
+// (*Client).RawRequestContext otherwise.
+func RawRequest(URL string, r io.Reader) (*http.Response, error) {
+	return defaultClient.RawRequest(URL, r)
+}
+
 // Request marshals a Request object into XML, makes an HTTP request against
 // it's URL, and then unmarshals the response into a Reaponse object.
 //
@@ -74,51 +142,108 @@ func RawRequest(URL string, r io.Reader) (*http.Response, error) {
 // updated in place in the supplied Request object so they may later be
 // inspected by the caller.
 func (c *Client) Request(r *Request) (*Response, error) {
+	return c.RequestContext(context.Background(), r)
+}
+
+// RequestContext is identical to Request, except that it allows the caller
+// to pass a context.Context to bound/cancel the underlying HTTP request
+// (for example, to enforce a timeout or allow early cancellation).
+func (c *Client) RequestContext(ctx context.Context, r *Request) (*Response, error) {
 	r.Signon.DtClient = Date(time.Now())
 
 	// Overwrite fields that the client controls
-	r.Version = c.OfxVersion()
+	r.Version = c.OfxVersion() // falls back to the version seen by a prior Profile call
 	r.Signon.AppId = c.Id()
 	r.Signon.AppVer = c.Version()
 	r.indent = c.IndentRequests()
 
+	// Drop message sets the FI's cached profile doesn't advertise support
+	// for, rather than sending them and letting the FI reject the whole
+	// request with a generic error.
+	c.pruneUnsupportedMessageSets(r)
+
 	b, err := r.Marshal()
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := RawRequest(r.URL, b)
+	var reqBytes []byte
+	if c.CarriageReturnNewLines {
+		reqBytes = bytes.Replace(b.Bytes(), []byte("\n"), []byte("\r\n"), -1)
+	} else {
+		reqBytes = b.Bytes()
+	}
+
+	response, err := c.RawRequestContext(ctx, r.URL, bytes.NewReader(reqBytes))
 	if err != nil {
 		return nil, err
 	}
 	defer response.Body.Close()
 
-	ofxresp, err := ParseResponse(response.Body)
+	var body io.Reader = response.Body
+	if c.RequestDump != nil {
+		respBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.RequestDump(reqBytes, respBytes)
+		body = bytes.NewReader(respBytes)
+	}
+
+	ofxresp, err := ParseResponse(body)
 	if err != nil {
 		return nil, err
 	}
 	return ofxresp, nil
 }
 
+// pruneUnsupportedMessageSets nils out the fields of r that CachedProfile
+// says the FI doesn't advertise support for in its MSGSETLIST, so
+// RequestContext doesn't send message sets the FI is just going to reject
+// with a generic error. It's a no-op until a prior call to Profile (or
+// ProfileContext) has populated c.CachedProfile.
+func (c *Client) pruneUnsupportedMessageSets(r *Request) {
+	if c.CachedProfile == nil {
+		return
+	}
+	msl := c.CachedProfile.MsgSetList
+
+	if len(r.Signup) > 0 && len(msl.Signup) == 0 {
+		r.Signup = nil
+	}
+	if len(r.Banking) > 0 && len(msl.Bank) == 0 {
+		r.Banking = nil
+	}
+	if len(r.CreditCard) > 0 && len(msl.CreditCard) == 0 {
+		r.CreditCard = nil
+	}
+	if len(r.InvStmt) > 0 && len(msl.InvStmt) == 0 {
+		r.InvStmt = nil
+	}
+	if len(r.SecList) > 0 && len(msl.Seclist) == 0 {
+		r.SecList = nil
+	}
+}
+
 type Message interface {
 	Name() string
 	Valid() (bool, error)
 }
 
 type Request struct {
-	URL     string
-	Version string        // String for OFX header, defaults to 203
-	Signon  SignonRequest //<SIGNONMSGSETV1>
-	Signup  []Message     //<SIGNUPMSGSETV1>
-	Banking []Message     //<BANKMSGSETV1>
-	//<CREDITCARDMSGSETV1>
+	URL        string
+	Version    string        // String for OFX header, defaults to 203
+	Signon     SignonRequest //<SIGNONMSGSETV1>
+	Signup     []Message     //<SIGNUPMSGSETV1>
+	Banking    []Message     //<BANKMSGSETV1>
+	CreditCard []Message     //<CREDITCARDMSGSETV1>
 	//<LOANMSGSETV1>
-	//<INVSTMTMSGSETV1>
+	InvStmt []Message //<INVSTMTMSGSETV1>
 	//<INTERXFERMSGSETV1>
 	//<WIREXFERMSGSETV1>
 	//<BILLPAYMSGSETV1>
 	//<EMAILMSGSETV1>
-	//<SECLISTMSGSETV1>
+	SecList []Message //<SECLISTMSGSETV1>
 	//<PRESDIRMSGSETV1>
 	//<PRESDLVMSGSETV1>
 	Profile []Message //<PROFMSGSETV1>
@@ -171,7 +296,7 @@ NEWFILEUID:NONE
 		b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
 		b.WriteString(`<?OFX OFXHEADER="200" VERSION="` + oq.Version + `" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
 	default:
-		return nil, errors.New(oq.Version + " is not a valid OFX version string")
+		return nil, &UnsupportedVersionError{Version: oq.Version}
 	}
 
 	encoder := xml.NewEncoder(&b)
@@ -205,6 +330,15 @@ NEWFILEUID:NONE
 	if err := oq.marshalMessageSet(encoder, oq.Banking, "BANKMSGSRQV1"); err != nil {
 		return nil, err
 	}
+	if err := oq.marshalMessageSet(encoder, oq.CreditCard, "CREDITCARDMSGSRQV1"); err != nil {
+		return nil, err
+	}
+	if err := oq.marshalMessageSet(encoder, oq.InvStmt, "INVSTMTMSGSRQV1"); err != nil {
+		return nil, err
+	}
+	if err := oq.marshalMessageSet(encoder, oq.SecList, "SECLISTMSGSRQV1"); err != nil {
+		return nil, err
+	}
 	if err := oq.marshalMessageSet(encoder, oq.Profile, "PROFMSGSRQV1"); err != nil {
 		return nil, err
 	}
@@ -220,30 +354,35 @@ NEWFILEUID:NONE
 }
 
 type Response struct {
-	Version string         // String for OFX header, defaults to 203
-	Signon  SignonResponse //<SIGNONMSGSETV1>
-	Signup  []Message      //<SIGNUPMSGSETV1>
-	Banking []Message      //<BANKMSGSETV1>
-	//<CREDITCARDMSGSETV1>
+	Version    string         // String for OFX header, defaults to 203
+	Signon     SignonResponse //<SIGNONMSGSETV1>
+	Signup     []Message      //<SIGNUPMSGSETV1>
+	Banking    []Message      //<BANKMSGSETV1>
+	CreditCard []Message      //<CREDITCARDMSGSETV1>
 	//<LOANMSGSETV1>
-	//<INVSTMTMSGSETV1>
+	InvStmt []Message //<INVSTMTMSGSETV1>
 	//<INTERXFERMSGSETV1>
 	//<WIREXFERMSGSETV1>
 	//<BILLPAYMSGSETV1>
 	//<EMAILMSGSETV1>
-	//<SECLISTMSGSETV1>
+	SecList []Message //<SECLISTMSGSETV1>
 	//<PRESDIRMSGSETV1>
 	//<PRESDLVMSGSETV1>
 	Profile []Message //<PROFMSGSETV1>
 	//<IMAGEMSGSETV1>
 }
 
-func (or *Response) readSGMLHeaders(r *bufio.Reader) error {
+// readSGMLHeaderVersion reads and validates the SGML header block (ending
+// at the first blank line) from r, returning the OFX VERSION it declares.
+// It's shared by ParseResponse and ResponseDecoder so the two have
+// identical header-parsing behavior.
+func readSGMLHeaderVersion(r *bufio.Reader) (string, error) {
+	var version string
 	var seenHeader, seenVersion bool = false, false
 	for {
 		line, err := r.ReadString('\n')
 		if err != nil {
-			return err
+			return "", err
 		}
 		// r.ReadString leaves the '\n' on the end...
 		line = strings.TrimSpace(line)
@@ -257,45 +396,54 @@ func (or *Response) readSGMLHeaders(r *bufio.Reader) error {
 		}
 		header := strings.SplitN(line, ":", 2)
 		if header == nil || len(header) != 2 {
-			return errors.New("OFX headers malformed")
+			return "", &HeaderError{Message: "OFX headers malformed"}
 		}
 
 		switch header[0] {
 		case "OFXHEADER":
 			if header[1] != "100" {
-				return errors.New("OFXHEADER is not 100")
+				return "", &HeaderError{Header: header[0], Value: header[1], Message: "OFXHEADER is not 100"}
 			}
 			seenHeader = true
 		case "DATA":
 			if header[1] != "OFXSGML" {
-				return errors.New("OFX DATA header does not contain OFXSGML")
+				return "", &HeaderError{Header: header[0], Value: header[1], Message: "OFX DATA header does not contain OFXSGML"}
 			}
 		case "VERSION":
 			switch header[1] {
 			case "102", "103", "151", "160":
 				seenVersion = true
-				or.Version = header[1]
+				version = header[1]
 			default:
-				return errors.New("Invalid OFX VERSION in header")
+				return "", &HeaderError{Header: header[0], Value: header[1], Message: "Invalid OFX VERSION in header"}
 			}
 		case "SECURITY":
 			if header[1] != "NONE" {
-				return errors.New("OFX SECURITY header not NONE")
+				return "", &HeaderError{Header: header[0], Value: header[1], Message: "OFX SECURITY header not NONE"}
 			}
 		case "COMPRESSION":
 			if header[1] != "NONE" {
-				return errors.New("OFX COMPRESSION header not NONE")
+				return "", &HeaderError{Header: header[0], Value: header[1], Message: "OFX COMPRESSION header not NONE"}
 			}
 		case "ENCODING", "CHARSET", "OLDFILEUID", "NEWFILEUID":
 			// TODO check/handle these headers?
 		default:
-			return errors.New("Invalid OFX header: " + header[0])
+			return "", &HeaderError{Header: header[0], Value: header[1], Message: "Invalid OFX header"}
 		}
 	}
 
 	if !seenVersion {
-		return errors.New("OFX VERSION header missing")
+		return "", &HeaderError{Message: "OFX VERSION header missing"}
 	}
+	return version, nil
+}
+
+func (or *Response) readSGMLHeaders(r *bufio.Reader) error {
+	version, err := readSGMLHeaderVersion(r)
+	if err != nil {
+		return err
+	}
+	or.Version = version
 	return nil
 }
 
@@ -315,19 +463,24 @@ func nextNonWhitespaceToken(decoder *xml.Decoder) (xml.Token, error) {
 	}
 }
 
-func (or *Response) readXMLHeaders(decoder *xml.Decoder) error {
+// readXMLHeaderVersion reads and validates the "<?xml ...?>" and
+// "<?OFX ...?>" processing instructions from decoder, returning the OFX
+// VERSION the latter declares. It's shared by ParseResponse and
+// ResponseDecoder so the two have identical header-parsing behavior.
+func readXMLHeaderVersion(decoder *xml.Decoder) (string, error) {
+	var version string
 	var tok xml.Token
 	tok, err := nextNonWhitespaceToken(decoder)
 	if err != nil {
-		return err
+		return "", err
 	} else if xmlElem, ok := tok.(xml.ProcInst); !ok || xmlElem.Target != "xml" {
-		return errors.New("Missing xml processing instruction")
+		return "", &HeaderError{Message: "Missing xml processing instruction"}
 	}
 
 	// parse the OFX header
 	tok, err = nextNonWhitespaceToken(decoder)
 	if err != nil {
-		return err
+		return "", err
 	} else if ofxElem, ok := tok.(xml.ProcInst); ok && ofxElem.Target == "OFX" {
 		var seenHeader, seenVersion bool = false, false
 
@@ -335,13 +488,13 @@ func (or *Response) readXMLHeaders(decoder *xml.Decoder) error {
 		for len(headers) > 0 {
 			tmp := bytes.SplitN(headers, []byte("=\""), 2)
 			if len(tmp) != 2 {
-				return errors.New("Malformed OFX header")
+				return "", &HeaderError{Message: "Malformed OFX header"}
 			}
 			header := string(tmp[0])
 			headers = tmp[1]
 			tmp = bytes.SplitN(headers, []byte("\""), 2)
 			if len(tmp) != 2 {
-				return errors.New("Malformed OFX header")
+				return "", &HeaderError{Message: "Malformed OFX header"}
 			}
 			value := string(tmp[0])
 			headers = bytes.TrimSpace(tmp[1])
@@ -349,38 +502,47 @@ func (or *Response) readXMLHeaders(decoder *xml.Decoder) error {
 			switch header {
 			case "OFXHEADER":
 				if value != "200" {
-					return errors.New("OFXHEADER is not 200")
+					return "", &HeaderError{Header: header, Value: value, Message: "OFXHEADER is not 200"}
 				}
 				seenHeader = true
 			case "VERSION":
 				switch value {
 				case "200", "201", "202", "203", "210", "211", "220":
 					seenVersion = true
-					or.Version = value
+					version = value
 				default:
-					return errors.New("Invalid OFX VERSION in header")
+					return "", &HeaderError{Header: header, Value: value, Message: "Invalid OFX VERSION in header"}
 				}
 			case "SECURITY":
 				if value != "NONE" {
-					return errors.New("OFX SECURITY header not NONE")
+					return "", &HeaderError{Header: header, Value: value, Message: "OFX SECURITY header not NONE"}
 				}
 			case "OLDFILEUID", "NEWFILEUID":
 				// TODO check/handle these headers?
 			default:
-				return errors.New("Invalid OFX header: " + header)
+				return "", &HeaderError{Header: header, Value: value, Message: "Invalid OFX header"}
 			}
 		}
 
 		if !seenHeader {
-			return errors.New("OFXHEADER version missing")
+			return "", &HeaderError{Message: "OFXHEADER version missing"}
 		}
 		if !seenVersion {
-			return errors.New("OFX VERSION header missing")
+			return "", &HeaderError{Message: "OFX VERSION header missing"}
 		}
 
 	} else {
-		return errors.New("Missing xml 'OFX' processing instruction")
+		return "", &HeaderError{Message: "Missing xml 'OFX' processing instruction"}
 	}
+	return version, nil
+}
+
+func (or *Response) readXMLHeaders(decoder *xml.Decoder) error {
+	version, err := readXMLHeaderVersion(decoder)
+	if err != nil {
+		return err
+	}
+	or.Version = version
 	return nil
 }
 
@@ -391,7 +553,7 @@ const guessVersionCheckBytes = 1024
 func guessVersion(r *bufio.Reader) (bool, error) {
 	b, _ := r.Peek(guessVersionCheckBytes)
 	if b == nil {
-		return false, errors.New("Failed to read OFX header")
+		return false, &HeaderError{Message: "Failed to read OFX header"}
 	}
 	sgmlIndex := bytes.Index(b, []byte("OFXHEADER:"))
 	xmlIndex := bytes.Index(b, []byte("OFXHEADER="))
@@ -427,11 +589,11 @@ func ParseResponse(reader io.Reader) (*Response, error) {
 		}
 	}
 
-	decoder := xml.NewDecoder(r)
+	var xmlReader io.Reader = r
 	if !xmlVersion {
-		decoder.Strict = false
-		decoder.AutoCloseAfterCharData = ofxLeafElements
+		xmlReader = newSGMLToXMLReader(r)
 	}
+	decoder := xml.NewDecoder(xmlReader)
 	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
 		return input, nil
 	}
@@ -447,7 +609,7 @@ func ParseResponse(reader io.Reader) (*Response, error) {
 	if err != nil {
 		return nil, err
 	} else if ofxStart, ok := tok.(xml.StartElement); !ok || ofxStart.Name.Local != "OFX" {
-		return nil, errors.New("Missing opening OFX xml element")
+		return nil, &UnexpectedElementError{Expected: "opening OFX xml element"}
 	}
 
 	// Unmarshal the signon message
@@ -459,18 +621,21 @@ func ParseResponse(reader io.Reader) (*Response, error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New("Missing opening SIGNONMSGSRSV1 xml element")
+		return nil, &UnexpectedElementError{Expected: "opening SIGNONMSGSRSV1 xml element"}
 	}
 
 	tok, err = nextNonWhitespaceToken(decoder)
 	if err != nil {
 		return nil, err
 	} else if signonEnd, ok := tok.(xml.EndElement); !ok || signonEnd.Name.Local != "SIGNONMSGSRSV1" {
-		return nil, errors.New("Missing closing SIGNONMSGSRSV1 xml element")
+		return nil, &UnexpectedElementError{Expected: "closing SIGNONMSGSRSV1 xml element"}
 	}
 	if ok, err := or.Signon.Valid(); !ok {
 		return nil, err
 	}
+	if or.Signon.Status.Code != 0 {
+		return nil, &StatusError{Status: or.Signon.Status}
+	}
 
 	for {
 		tok, err = nextNonWhitespaceToken(decoder)
@@ -479,42 +644,64 @@ func ParseResponse(reader io.Reader) (*Response, error) {
 		} else if ofxEnd, ok := tok.(xml.EndElement); ok && ofxEnd.Name.Local == "OFX" {
 			return &or, nil // found closing XML element, so we're done
 		} else if start, ok := tok.(xml.StartElement); ok {
-			// TODO decode other types
-			switch start.Name.Local {
+			setName, msgs, err := decodeMessageSet(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			switch setName {
 			case "SIGNUPMSGSRSV1":
-				msgs, err := DecodeSignupMessageSet(decoder, start)
-				if err != nil {
-					return nil, err
-				}
 				or.Signup = msgs
 			case "BANKMSGSRSV1":
-				msgs, err := DecodeBankingMessageSet(decoder, start)
-				if err != nil {
-					return nil, err
-				}
 				or.Banking = msgs
-			//case "CREDITCARDMSGSRSV1":
-			//case "LOANMSGSRSV1":
-			//case "INVSTMTMSGSRSV1":
-			//case "INTERXFERMSGSRSV1":
-			//case "WIREXFERMSGSRSV1":
-			//case "BILLPAYMSGSRSV1":
-			//case "EMAILMSGSRSV1":
-			//case "SECLISTMSGSRSV1":
-			//case "PRESDIRMSGSRSV1":
-			//case "PRESDLVMSGSRSV1":
+			case "CREDITCARDMSGSRSV1":
+				or.CreditCard = msgs
+			case "INVSTMTMSGSRSV1":
+				or.InvStmt = msgs
+			case "SECLISTMSGSRSV1":
+				or.SecList = msgs
 			case "PROFMSGSRSV1":
-				msgs, err := DecodeProfileMessageSet(decoder, start)
-				if err != nil {
-					return nil, err
-				}
 				or.Profile = msgs
-			//case "IMAGEMSGSRSV1":
-			default:
-				return nil, errors.New("Unsupported message set: " + start.Name.Local)
 			}
 		} else {
-			return nil, errors.New("Found unexpected token")
+			return nil, &UnexpectedElementError{Expected: "start or end element"}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// decodeMessageSet dispatches a *_MSGSRSV1 start element to the
+// corresponding DecodeXxxMessageSet function, returning the element's name
+// alongside the Messages it contained. It's shared by ParseResponse and
+// ResponseDecoder so the two decode message sets identically.
+func decodeMessageSet(decoder *xml.Decoder, start xml.StartElement) (string, []Message, error) {
+	// TODO decode other message set types
+	switch start.Name.Local {
+	case "SIGNUPMSGSRSV1":
+		msgs, err := DecodeSignupMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	case "BANKMSGSRSV1":
+		msgs, err := DecodeBankingMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	case "CREDITCARDMSGSRSV1":
+		msgs, err := DecodeCreditCardMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	//case "LOANMSGSRSV1":
+	case "INVSTMTMSGSRSV1":
+		msgs, err := DecodeInvStmtMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	//case "INTERXFERMSGSRSV1":
+	//case "WIREXFERMSGSRSV1":
+	//case "BILLPAYMSGSRSV1":
+	//case "EMAILMSGSRSV1":
+	case "SECLISTMSGSRSV1":
+		msgs, err := DecodeSecListMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	//case "PRESDIRMSGSRSV1":
+	//case "PRESDLVMSGSRSV1":
+	case "PROFMSGSRSV1":
+		msgs, err := DecodeProfileMessageSet(decoder, start)
+		return start.Name.Local, msgs, err
+	//case "IMAGEMSGSRSV1":
+	default:
+		return "", nil, &UnsupportedMessageSetError{MessageSet: start.Name.Local}
+	}
+}
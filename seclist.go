@@ -0,0 +1,130 @@
+package ofxgo
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// SecId uniquely identifies a security, as used throughout the investment
+// and securities list message sets (SECID).
+type SecId struct {
+	UniqueId     String `xml:"UNIQUEID"`
+	UniqueIdType String `xml:"UNIQUEIDTYPE"`
+}
+
+func (s *SecId) Valid() (bool, error) {
+	if len(s.UniqueId) == 0 {
+		return false, errors.New("SecId: UNIQUEID unset")
+	}
+	if len(s.UniqueIdType) == 0 {
+		return false, errors.New("SecId: UNIQUEIDTYPE unset")
+	}
+	return true, nil
+}
+
+// SecRequest represents a SECRQ, a single security request inside a
+// SecListRequest. Exactly one of SecId or Ticker should be set.
+type SecRequest struct {
+	SecId  *SecId `xml:"SECID,omitempty"`
+	Ticker String `xml:"TICKER,omitempty"`
+}
+
+// SecListRequest represents a SECLISTTRNRQ, requesting information on a
+// list of securities (by SECID or ticker).
+type SecListRequest struct {
+	XMLName     xml.Name     `xml:"SECLISTTRNRQ"`
+	TrnUID      String       `xml:"TRNUID"`
+	CltCookie   String       `xml:"CLTCOOKIE,omitempty"`
+	SecRequests []SecRequest `xml:"SECLISTRQ>SECRQ"`
+}
+
+func (r *SecListRequest) Name() string {
+	return "SECLISTTRNRQ"
+}
+
+func (r *SecListRequest) Valid() (bool, error) {
+	if len(r.TrnUID) == 0 {
+		return false, errors.New("SecListRequest: TrnUID unset")
+	}
+	return true, nil
+}
+
+// StockInfo represents a STOCKINFO, describing a single stock security.
+type StockInfo struct {
+	SecId      SecId  `xml:"SECINFO>SECID"`
+	SecName    String `xml:"SECINFO>SECNAME"`
+	Ticker     String `xml:"SECINFO>TICKER,omitempty"`
+	UnitPrice  Amount `xml:"SECINFO>UNITPRICE,omitempty"`
+	AssetClass String `xml:"ASSETCLASS,omitempty"`
+}
+
+func (s *StockInfo) Name() string {
+	return "STOCKINFO"
+}
+
+func (s *StockInfo) Valid() (bool, error) {
+	return s.SecId.Valid()
+}
+
+// MFInfo represents an MFINFO, describing a single mutual fund security.
+type MFInfo struct {
+	SecId     SecId  `xml:"SECINFO>SECID"`
+	SecName   String `xml:"SECINFO>SECNAME"`
+	Ticker    String `xml:"SECINFO>TICKER,omitempty"`
+	UnitPrice Amount `xml:"SECINFO>UNITPRICE,omitempty"`
+	MFType    String `xml:"MFTYPE,omitempty"`
+}
+
+func (m *MFInfo) Name() string {
+	return "MFINFO"
+}
+
+func (m *MFInfo) Valid() (bool, error) {
+	return m.SecId.Valid()
+}
+
+// SecListResponse represents a SECLISTRS, the list of securities returned
+// by an FI. Unlike most OFX responses, SECLISTMSGSRSV1 carries its SECLISTRS
+// directly, with no enclosing TRNRS/TRNUID transaction wrapper.
+type SecListResponse struct {
+	XMLName    xml.Name    `xml:"SECLISTRS"`
+	StockInfos []StockInfo `xml:"STOCKINFO"`
+	MFInfos    []MFInfo    `xml:"MFINFO"`
+}
+
+func (s *SecListResponse) Name() string {
+	return "SECLISTRS"
+}
+
+func (s *SecListResponse) Valid() (bool, error) {
+	return true, nil
+}
+
+// DecodeSecListMessageSet consumes a SECLISTMSGSRSV1 element (and
+// everything inside it), returning the decoded messages it contains. Unlike
+// the other message sets, a SECLISTMSGSRSV1 holds at most one SECLISTRS,
+// with no per-transaction wrapper.
+func DecodeSecListMessageSet(d *xml.Decoder, start xml.StartElement) ([]Message, error) {
+	var msgs []Message
+	for {
+		tok, err := nextNonWhitespaceToken(d)
+		if err != nil {
+			return nil, err
+		} else if end, ok := tok.(xml.EndElement); ok && end.Name.Local == start.Name.Local {
+			return msgs, nil
+		} else if trnStart, ok := tok.(xml.StartElement); ok {
+			switch trnStart.Name.Local {
+			case "SECLISTRS":
+				var msg SecListResponse
+				if err := d.DecodeElement(&msg, &trnStart); err != nil {
+					return nil, err
+				}
+				msgs = append(msgs, &msg)
+			default:
+				return nil, &UnsupportedMessageSetError{MessageSet: trnStart.Name.Local}
+			}
+		} else {
+			return nil, &UnexpectedElementError{Expected: "start or end element"}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package ofxgo
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// ofxLeafElements lists the OFX SGML elements that only ever contain
+// character data (never child elements), and so are never explicitly
+// closed by FIs that follow the older OFX SGML convention. It isn't
+// exhaustive of every leaf tag in the OFX spec, just the ones ofxgo
+// currently decodes; an FI sending a leaf element missing from this list
+// produces malformed XML (and a decode error further down the pipeline)
+// rather than a silent mis-parse.
+var ofxLeafElements = map[string]bool{
+	"CODE":          true,
+	"SEVERITY":      true,
+	"MESSAGE":       true,
+	"DTSERVER":      true,
+	"LANGUAGE":      true,
+	"DTCLIENT":      true,
+	"USERID":        true,
+	"USERPASS":      true,
+	"ORG":           true,
+	"FID":           true,
+	"APPID":         true,
+	"APPVER":        true,
+	"TRNUID":        true,
+	"CLTCOOKIE":     true,
+	"BROKERID":      true,
+	"ACCTID":        true,
+	"DTSTART":       true,
+	"DTEND":         true,
+	"DTASOF":        true,
+	"DTPOSTED":      true,
+	"DTTRADE":       true,
+	"DTSETTLE":      true,
+	"DTPRICEASOF":   true,
+	"INCLUDE":       true,
+	"INCOO":         true,
+	"INCBAL":        true,
+	"CURDEF":        true,
+	"FITID":         true,
+	"SRVRTID":       true,
+	"TRNAMT":        true,
+	"NAME":          true,
+	"MEMO":          true,
+	"UNIQUEID":      true,
+	"UNIQUEIDTYPE":  true,
+	"TICKER":        true,
+	"SECNAME":       true,
+	"UNITPRICE":     true,
+	"ASSETCLASS":    true,
+	"MFTYPE":        true,
+	"HELDINACCT":    true,
+	"POSTYPE":       true,
+	"UNITS":         true,
+	"MKTVAL":        true,
+	"AVAILCASH":     true,
+	"MARGINBALANCE": true,
+	"SHORTBALANCE":  true,
+	"BALAMT":        true,
+	"DTASOFBAL":     true,
+	"COMMISSION":    true,
+	"TOTAL":         true,
+	"SUBACCTSEC":    true,
+	"SUBACCTFUND":   true,
+	"BUYTYPE":       true,
+	"SELLTYPE":      true,
+	"INCOMETYPE":    true,
+	"TAXEXEMPT":     true,
+}
+
+// sgmlToXMLReader wraps a bufio.Reader positioned just after the SGML
+// header block, and rewrites the lenient OFX SGML body it reads into
+// well-formed XML as it's consumed: leaf elements (those listed in
+// ofxLeafElements) are never explicitly closed in OFX SGML, so this
+// inserts the missing close tag for one as soon as the next tag (its
+// sibling's open tag, or its parent's close tag) makes clear that its
+// content has ended.
+//
+// This lets ParseResponse and ResponseDecoder hand the result to the
+// standard library's encoding/xml, rather than depending on a forked
+// decoder with a lenient mode of its own.
+//
+// Known limitations: it assumes OFX SGML's usual shape (no attributes, no
+// self-closing tags, no nested comments) and only escapes bare '&' in
+// character data; a document that violates those assumptions will produce
+// malformed XML rather than a clean error.
+type sgmlToXMLReader struct {
+	r     *bufio.Reader
+	stack []string
+	out   bytes.Buffer
+	eof   bool
+}
+
+func newSGMLToXMLReader(r *bufio.Reader) *sgmlToXMLReader {
+	return &sgmlToXMLReader{r: r}
+}
+
+func (s *sgmlToXMLReader) Read(p []byte) (int, error) {
+	for s.out.Len() == 0 && !s.eof {
+		if err := s.step(); err != nil {
+			if err == io.EOF {
+				s.eof = true
+				break
+			}
+			return 0, err
+		}
+	}
+	if s.out.Len() == 0 {
+		return 0, io.EOF
+	}
+	return s.out.Read(p)
+}
+
+// step consumes a single tag or run of character data from the underlying
+// reader, writing its (possibly auto-closed) XML equivalent to s.out.
+func (s *sgmlToXMLReader) step() error {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return s.closeRemaining(err)
+	}
+
+	if b != '<' {
+		var buf bytes.Buffer
+		buf.WriteByte(b)
+		for {
+			next, err := s.r.Peek(1)
+			if err != nil || next[0] == '<' {
+				break
+			}
+			c, _ := s.r.ReadByte()
+			buf.WriteByte(c)
+		}
+		s.out.WriteString(escapeAmpersands(buf.String()))
+		return nil
+	}
+
+	tag, err := s.r.ReadString('>')
+	if err != nil {
+		return err
+	}
+	tag = tag[:len(tag)-1]
+
+	if strings.HasPrefix(tag, "/") {
+		return s.closeTag(strings.TrimPrefix(tag, "/"))
+	}
+	if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+		s.out.WriteString("<" + tag + ">")
+		return nil
+	}
+	return s.openTag(tag)
+}
+
+// openTag handles a non-closing "<NAME>" tag: if the currently-open
+// element is a leaf, its content has just ended, so it's closed first.
+func (s *sgmlToXMLReader) openTag(name string) error {
+	for len(s.stack) > 0 && ofxLeafElements[s.stack[len(s.stack)-1]] {
+		top := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		s.out.WriteString("</" + top + ">")
+	}
+	s.stack = append(s.stack, name)
+	s.out.WriteString("<" + name + ">")
+	return nil
+}
+
+// closeTag handles an explicit "</NAME>" tag, auto-closing any open leaf
+// elements on the stack until NAME itself is found and closed.
+func (s *sgmlToXMLReader) closeTag(name string) error {
+	for len(s.stack) > 0 {
+		top := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		s.out.WriteString("</" + top + ">")
+		if top == name {
+			return nil
+		}
+		if !ofxLeafElements[top] {
+			return &UnexpectedElementError{Expected: "closing tag for " + top, Found: "</" + name + ">"}
+		}
+	}
+	return &UnexpectedElementError{Expected: "open element matching </" + name + ">"}
+}
+
+// closeRemaining is called once the underlying reader is exhausted; any
+// leaf elements still open (most commonly the last child before a final
+// </OFX> that never arrives) are auto-closed, then the original
+// error (normally io.EOF) is returned.
+func (s *sgmlToXMLReader) closeRemaining(err error) error {
+	for len(s.stack) > 0 && ofxLeafElements[s.stack[len(s.stack)-1]] {
+		top := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		s.out.WriteString("</" + top + ">")
+	}
+	return err
+}
+
+func escapeAmpersands(s string) string {
+	if !strings.ContainsRune(s, '&') {
+		return s
+	}
+	// An '&' that already starts a predefined or numeric character
+	// reference is passed through as-is.
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteByte(s[i])
+		if s[i] != '&' {
+			continue
+		}
+		rest := s[i+1:]
+		isRef := false
+		for _, ref := range []string{"amp;", "lt;", "gt;", "quot;", "apos;", "#"} {
+			isRef = isRef || strings.HasPrefix(rest, ref)
+		}
+		if !isRef {
+			b.WriteString("amp;")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package ofxgo
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func convertSGML(t *testing.T, sgml string) (string, error) {
+	t.Helper()
+	r := newSGMLToXMLReader(bufio.NewReader(strings.NewReader(sgml)))
+	out, err := ioutil.ReadAll(r)
+	return string(out), err
+}
+
+func TestSGMLToXMLNestedLeaves(t *testing.T) {
+	const in = `<SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20060115000000<LANGUAGE>ENG</SONRS>`
+	const want = `<SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>20060115000000</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS>`
+
+	got, err := convertSGML(t, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSGMLToXMLSiblingCloses(t *testing.T) {
+	// FITID's content ends where its sibling DTTRADE's opening tag begins;
+	// DTTRADE in turn is closed implicitly by INVTRAN's explicit close tag.
+	const in = `<INVTRAN><FITID>1<DTTRADE>20060105000000</INVTRAN>`
+	const want = `<INVTRAN><FITID>1</FITID><DTTRADE>20060105000000</DTTRADE></INVTRAN>`
+
+	got, err := convertSGML(t, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSGMLToXMLExplicitSiblingCloseOfLeaf(t *testing.T) {
+	// A leaf element that IS explicitly closed (some FIs do this) should
+	// decode the same as one that relies on implicit closing.
+	const in = `<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>`
+	const want = `<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>`
+
+	got, err := convertSGML(t, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSGMLToXMLMismatchedCloseTag(t *testing.T) {
+	// SONRS is never opened, so closing it while BROKERID (a leaf) and
+	// INVACCTFROM are still open should surface an UnexpectedElementError,
+	// not silently close the wrong element.
+	const in = `<INVACCTFROM><BROKERID>example.com</SONRS>`
+
+	_, err := convertSGML(t, in)
+	if err == nil {
+		t.Fatalf("expected an error for a mismatched close tag, got none")
+	}
+	if _, ok := err.(*UnexpectedElementError); !ok {
+		t.Errorf("got error of type %T, want *UnexpectedElementError", err)
+	}
+}
+
+func TestSGMLToXMLMissingFinalCloseTag(t *testing.T) {
+	// closeRemaining exists for exactly this case: the reader runs out
+	// (io.EOF) with a leaf element still open on the stack, most commonly
+	// the last child before a final </OFX> that never arrives.
+	const in = `<OFX><SIGNONMSGSRSV1><SONRS><LANGUAGE>ENG`
+	const want = `<OFX><SIGNONMSGSRSV1><SONRS><LANGUAGE>ENG</LANGUAGE>`
+
+	got, err := convertSGML(t, in)
+	if err != nil && err != io.EOF {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSGMLToXMLEscapesBareAmpersand(t *testing.T) {
+	const in = `<MEMO>Smith & Sons</MEMO>`
+	const want = `<MEMO>Smith &amp; Sons</MEMO>`
+
+	got, err := convertSGML(t, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}